@@ -0,0 +1,49 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Export guts for testing, for the decimal/BID and engineering-notation
+// pieces added alongside ftoa_test.go.
+//
+// ftoa_test.go lives in package strconv_test (it imports "testing", and
+// testing itself imports strconv, so a white-box strconv test package
+// that also imports "testing" would be an import cycle). This file has
+// no "testing" import, so it can live in package strconv and hand the
+// black-box tests the unexported pieces they need to exercise directly.
+// It is named decimal_export_test.go rather than export_test.go because
+// the package already has an export_test.go exporting unrelated pieces
+// (BitSizeError, BaseError) for atoi_test.go; a second file of the same
+// name isn't possible, and this one stays scoped to what it exports.
+
+package strconv
+
+type DecimalSlice = decimalSlice
+
+// NewDecimalSlice builds a decimalSlice from literal digits, nd, and dp,
+// for tests that want to drive fmtN/fmtS/fmtE without going through a
+// real Ryu or bigFtoa pass.
+func NewDecimalSlice(d []byte, nd, dp int) DecimalSlice {
+	return DecimalSlice{d: d, nd: nd, dp: dp}
+}
+
+type DecimalInfo = decimalInfo
+
+var (
+	Decimal64Info  = decimal64info
+	Decimal128Info = decimal128info
+)
+
+const (
+	DecSpecialNone = decSpecialNone
+	DecSpecialInf  = decSpecialInf
+	DecSpecialNaN  = decSpecialNaN
+)
+
+var (
+	AppendGrouped = appendGrouped
+	AppendLocale  = appendLocale
+	FmtE          = fmtE
+	FmtN          = fmtN
+	FmtS          = fmtS
+	DecodeBID     = decodeBID
+)
@@ -10,7 +10,10 @@
 
 package strconv
 
-import "math"
+import (
+	"math"
+	"math/bits"
+)
 
 // TODO: move elsewhere?
 type floatInfo struct {
@@ -34,14 +37,22 @@ var float64info = floatInfo{52, 11, -1023}
 // 'f' (-ddd.dddd, no exponent),
 // 'g' ('e' for large exponents, 'f' otherwise),
 // 'G' ('E' for large exponents, 'f' otherwise),
-// 'x' (-0xd.ddddp±ddd, a hexadecimal fraction and binary exponent), or
-// 'X' (-0Xd.ddddP±ddd, a hexadecimal fraction and binary exponent).
+// 'x' (-0xd.ddddp±ddd, a hexadecimal fraction and binary exponent),
+// 'X' (-0Xd.ddddP±ddd, a hexadecimal fraction and binary exponent),
+// 'n' (-ddd.ddde±dd, engineering notation with the exponent forced to a
+// multiple of 3), or
+// 's' (-ddd.ddd followed by an SI prefix such as k, M, or µ, falling back
+// to 'e' notation with the same significant digits when the magnitude is
+// outside the y..Y prefix range).
 //
 // The precision prec controls the number of digits (excluding the exponent)
 // printed by the 'e', 'E', 'f', 'g', 'G', 'x', and 'X' formats.
 // For 'e', 'E', 'f', 'x', and 'X', it is the number of digits after the decimal point.
 // For 'g' and 'G' it is the maximum number of significant digits (trailing
 // zeros are removed).
+// For 'n' and 's' it is the number of digits after the decimal point,
+// counted after the 1, 2, or 3 leading digits that remain once the
+// exponent is rounded down to a multiple of 3.
 // The special precision -1 uses the smallest number of digits
 // necessary such that ParseFloat will return f exactly.
 func FormatFloat(f float64, fmt byte, prec, bitSize int) string {
@@ -54,7 +65,41 @@ func AppendFloat(dst []byte, f float64, fmt byte, prec, bitSize int) []byte {
 	return genericFtoa(dst, f, fmt, prec, bitSize)
 }
 
+// A FloatFormatter formats floating-point numbers the same way AppendFloat
+// does, but owns its scratch space so that repeated calls on a hot path —
+// a JSON encoder, a log formatter, a CSV writer — can run without any
+// per-call heap allocation. The zero value is ready to use.
+//
+// A FloatFormatter is not safe for concurrent use by multiple goroutines.
+type FloatFormatter struct {
+	dec decimal
+	buf [32]byte
+}
+
+// Reset clears the formatter's scratch decimal, discarding any state left
+// behind by an abandoned or panicking call to Format. It is not required
+// between ordinary calls to Format.
+func (fo *FloatFormatter) Reset() {
+	fo.dec = decimal{}
+}
+
+// Format appends the string form of f, as generated by AppendFloat, to
+// dst and returns the extended buffer, reusing fo's scratch space instead
+// of allocating it.
+func (fo *FloatFormatter) Format(dst []byte, f float64, fmt byte, prec, bitSize int) []byte {
+	return appendFtoa(dst, f, fmt, prec, bitSize, &fo.dec, fo.buf[:])
+}
+
 func genericFtoa(dst []byte, val float64, fmt byte, prec, bitSize int) []byte {
+	return appendFtoa(dst, val, fmt, prec, bitSize, nil, nil)
+}
+
+// appendFtoa is genericFtoa's implementation, parameterized over an
+// optional caller-owned decimal and Ryu scratch buffer. genericFtoa
+// passes nil for both, preserving today's stack-allocated behavior;
+// FloatFormatter passes its own fields so that repeated calls reuse the
+// same scratch space instead of allocating it each time.
+func appendFtoa(dst []byte, val float64, fmt byte, prec, bitSize int, scratch *decimal, rbuf []byte) []byte {
 	var bits uint64
 	var flt *floatInfo
 	switch bitSize {
@@ -105,7 +150,7 @@ func genericFtoa(dst []byte, val float64, fmt byte, prec, bitSize int) []byte {
 	}
 
 	if !optimize {
-		return bigFtoa(dst, prec, fmt, neg, mant, exp, flt)
+		return bigFtoa(scratch, dst, prec, fmt, neg, mant, exp, flt)
 	}
 
 	var digs decimalSlice
@@ -114,14 +159,20 @@ func genericFtoa(dst []byte, val float64, fmt byte, prec, bitSize int) []byte {
 	shortest := prec < 0
 	if shortest {
 		// Use Ryu algorithm.
-		var buf [32]byte
-		digs.d = buf[:]
+		if len(rbuf) >= 32 {
+			digs.d = rbuf[:32]
+		} else {
+			var buf [32]byte
+			digs.d = buf[:]
+		}
 		ryuFtoaShortest(&digs, mant, exp-int(flt.mantbits), flt)
 		ok = true
 		// Precision for shortest representation mode.
 		switch fmt {
 		case 'e', 'E':
 			prec = max(digs.nd-1, 0)
+		case 'n', 's':
+			prec = max(digs.nd-engineeringLead(digs.dp, digs.nd), 0)
 		case 'f':
 			prec = max(digs.nd-digs.dp, 0)
 		case 'g', 'G':
@@ -133,6 +184,16 @@ func genericFtoa(dst []byte, val float64, fmt byte, prec, bitSize int) []byte {
 		switch fmt {
 		case 'e', 'E':
 			digits++
+		case 'n', 's':
+			// Engineering/SI notation can show 1, 2, or 3 leading
+			// digits depending on where the exponent's mod-3 phase
+			// lands; learn that from a cheap shortest-mode Ryu pass
+			// (mirrors the 'f' branch below) so the fixed-digit
+			// request asks for enough digits to keep all prec of
+			// them after the point, instead of silently losing
+			// digits to whichever leading positions eng borrows.
+			sdp, snd := quickShortestDP(mant, exp-int(flt.mantbits), flt, rbuf)
+			digits = engineeringLead(sdp, snd) + prec
 		case 'g', 'G':
 			if prec == 0 {
 				prec = 1
@@ -142,26 +203,94 @@ func genericFtoa(dst []byte, val float64, fmt byte, prec, bitSize int) []byte {
 			// Invalid mode.
 			digits = 1
 		}
-		var buf [24]byte
+		var localBuf [24]byte
+		fbuf := localBuf[:]
+		if len(rbuf) >= 24 {
+			fbuf = rbuf[:24]
+		}
 		if bitSize == 32 && digits <= 9 {
-			digs.d = buf[:]
+			digs.d = fbuf
 			ryuFtoaFixed32(&digs, uint32(mant), exp-int(flt.mantbits), digits)
 			ok = true
 		} else if digits <= 18 {
-			digs.d = buf[:]
+			digs.d = fbuf
 			ryuFtoaFixed64(&digs, mant, exp-int(flt.mantbits), digits)
 			ok = true
 		}
+	} else {
+		// 'f' with a fixed number of digits after the decimal point.
+		// How many significant digits that takes depends on the exact
+		// (pre-rounding) decimal point position, which a cheap
+		// shortest-mode Ryu call gives us for free; ask Ryu's fixed path
+		// for exactly that many digits, the same way the branch above
+		// already does for 'e'/'g'/'G', so 'f' stays off the 800-byte
+		// bigFtoa path for the common case (mirrors bigFtoa's own
+		// d.Round(d.dp+prec), just sourcing the pre-round dp from Ryu
+		// instead of from decimal.Shift).
+		sdp, _ := quickShortestDP(mant, exp-int(flt.mantbits), flt, rbuf)
+
+		digits := sdp + prec
+		// digits < 1 means the rounding position sits at or above the
+		// most significant digit (e.g. 0.09 rounded to 1 decimal place):
+		// the result may need to round up into a new leading digit, which
+		// Ryu's fixed path can't do. Leave ok false and let bigFtoa, which
+		// rounds the full decimal instead of a fixed digit count, handle it.
+		if digits >= 1 {
+			var localBuf [24]byte
+			fbuf := localBuf[:]
+			if len(rbuf) >= 24 {
+				fbuf = rbuf[:24]
+			}
+			if bitSize == 32 && digits <= 9 {
+				digs.d = fbuf
+				ryuFtoaFixed32(&digs, uint32(mant), exp-int(flt.mantbits), digits)
+				ok = true
+			} else if digits <= 18 {
+				digs.d = fbuf
+				ryuFtoaFixed64(&digs, mant, exp-int(flt.mantbits), digits)
+				ok = true
+			}
+		}
 	}
 	if !ok {
-		return bigFtoa(dst, prec, fmt, neg, mant, exp, flt)
+		return bigFtoa(scratch, dst, prec, fmt, neg, mant, exp, flt)
 	}
 	return formatDigits(dst, shortest, neg, digs, prec, fmt)
 }
 
-// bigFtoa uses multiprecision computations to format a float.
-func bigFtoa(dst []byte, prec int, fmt byte, neg bool, mant uint64, exp int, flt *floatInfo) []byte {
-	d := new(decimal)
+// quickShortestDP runs a cheap shortest-mode Ryu pass whose only purpose
+// is to learn the pre-rounding decimal point position and digit count, for
+// fixed-digit callers ('f', and 'n'/'s') that need that to decide how many
+// digits to request from the real fixed-digit Ryu call. exp is already
+// adjusted by flt.mantbits, as ryuFtoaShortest expects.
+func quickShortestDP(mant uint64, exp int, flt *floatInfo, rbuf []byte) (dp, nd int) {
+	var sbuf [32]byte
+	sd := sbuf[:]
+	if len(rbuf) >= 32 {
+		sd = rbuf[:32]
+	}
+	var sdigs decimalSlice
+	sdigs.d = sd
+	ryuFtoaShortest(&sdigs, mant, exp, flt)
+	return sdigs.dp, sdigs.nd
+}
+
+// ShiftDecimal multiplies d by 10^exp, adjusting only the decimal point.
+// It is Shift's base-10 counterpart, used by AppendDecimal where the
+// exponent is already a power of ten instead of a power of two.
+func (d *decimal) ShiftDecimal(exp int) {
+	d.dp += exp
+}
+
+// bigFtoa uses multiprecision computations to format a float. scratch, if
+// non-nil, is reused instead of allocating a fresh decimal.
+func bigFtoa(scratch *decimal, dst []byte, prec int, fmt byte, neg bool, mant uint64, exp int, flt *floatInfo) []byte {
+	d := scratch
+	if d == nil {
+		d = new(decimal)
+	} else {
+		*d = decimal{}
+	}
 	d.Assign(mant)
 	d.Shift(exp - int(flt.mantbits))
 	var digs decimalSlice
@@ -173,6 +302,8 @@ func bigFtoa(dst []byte, prec int, fmt byte, neg bool, mant uint64, exp int, flt
 		switch fmt {
 		case 'e', 'E':
 			prec = digs.nd - 1
+		case 'n', 's':
+			prec = max(digs.nd-engineeringLead(digs.dp, digs.nd), 0)
 		case 'f':
 			prec = max(digs.nd-digs.dp, 0)
 		case 'g', 'G':
@@ -183,6 +314,8 @@ func bigFtoa(dst []byte, prec int, fmt byte, neg bool, mant uint64, exp int, flt
 		switch fmt {
 		case 'e', 'E':
 			d.Round(prec + 1)
+		case 'n', 's':
+			d.Round(engineeringLead(d.dp, d.nd) + prec)
 		case 'f':
 			d.Round(d.dp + prec)
 		case 'g', 'G':
@@ -202,6 +335,10 @@ func formatDigits(dst []byte, shortest bool, neg bool, digs decimalSlice, prec i
 		return fmtE(dst, neg, digs, prec, fmt)
 	case 'f':
 		return fmtF(dst, neg, digs, prec)
+	case 'n':
+		return fmtN(dst, neg, digs, prec)
+	case 's':
+		return fmtS(dst, neg, digs, prec)
 	case 'g', 'G':
 		// trailing fractional zeros in 'e' form will be trimmed.
 		eprec := prec
@@ -431,6 +568,110 @@ func fmtE(dst []byte, neg bool, d decimalSlice, prec int, fmt byte) []byte {
 	return dst
 }
 
+// appendEngineering writes the sign and the 1-, 2-, or 3-digit integer
+// part plus fractional digits shared by fmtN and fmtS: d's digits are the
+// same single-leading-digit stream used by fmtE, but the decimal point is
+// moved so that the reported exponent (the second return value) is a
+// multiple of 3.
+func appendEngineering(dst []byte, neg bool, d decimalSlice, prec int) ([]byte, int) {
+	if neg {
+		dst = append(dst, '-')
+	}
+
+	exp := d.dp - 1
+	if d.nd == 0 { // special case: 0 has exponent 0
+		exp = 0
+	}
+	lead := engineeringLead(d.dp, d.nd) // 1, 2, or 3 leading digits
+	eng := exp - (lead - 1)
+
+	for i := 0; i < lead; i++ {
+		ch := byte('0')
+		if i < d.nd {
+			ch = d.d[i]
+		}
+		dst = append(dst, ch)
+	}
+
+	// prec is the number of digits after the decimal point, independent
+	// of how many digits lead took from the integer part: a caller asking
+	// for 3 digits of precision gets 3 whatever the leading-digit count,
+	// not 3 minus however many lead borrowed.
+	if prec > 0 {
+		dst = append(dst, '.')
+		i := lead
+		m := min(d.nd, lead+prec)
+		if i < m {
+			dst = append(dst, d.d[i:m]...)
+			i = m
+		}
+		for ; i < lead+prec; i++ {
+			dst = append(dst, '0')
+		}
+	}
+
+	return dst, eng
+}
+
+// engineeringLead returns how many digits (1, 2, or 3) of a decimalSlice
+// with the given dp and nd fall before the decimal point in engineering
+// notation, i.e. one more than how far the exponent dp-1 needs to round
+// down to reach a multiple of 3.
+func engineeringLead(dp, nd int) int {
+	exp := dp - 1
+	if nd == 0 { // special case: 0 has exponent 0
+		exp = 0
+	}
+	eng := exp - ((exp%3 + 3) % 3)
+	return exp - eng + 1
+}
+
+// %n: -ddd.ddde±dd (engineering notation, exponent forced to a multiple of 3)
+func fmtN(dst []byte, neg bool, d decimalSlice, prec int) []byte {
+	dst, eng := appendEngineering(dst, neg, d, prec)
+
+	dst = append(dst, 'e')
+	ch := byte('+')
+	if eng < 0 {
+		ch = '-'
+		eng = -eng
+	}
+	dst = append(dst, ch)
+
+	switch {
+	case eng < 10:
+		dst = append(dst, '0', byte(eng)+'0')
+	case eng < 100:
+		dst = append(dst, byte(eng/10)+'0', byte(eng%10)+'0')
+	default:
+		dst = append(dst, byte(eng/100)+'0', byte(eng/10)%10+'0', byte(eng%10)+'0')
+	}
+	return dst
+}
+
+// siPrefixExp and siPrefix are parallel tables mapping an engineering
+// exponent (a multiple of 3, from -24 to 24) to its SI prefix glyph.
+var siPrefixExp = [...]int{-24, -21, -18, -15, -12, -9, -6, -3, 0, 3, 6, 9, 12, 15, 18, 21, 24}
+var siPrefix = [...]string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// %s: -ddd.ddd followed by an SI prefix (12.345k, 1.2µ), falling back to
+// 'e' notation when the magnitude falls outside the y..Y prefix range. The
+// fallback keeps the same significant digits, so it shows exp-eng more
+// digits after the point than prec.
+func fmtS(dst []byte, neg bool, d decimalSlice, prec int) []byte {
+	exp := d.dp - 1
+	if d.nd == 0 { // special case: 0 has exponent 0
+		exp = 0
+	}
+	eng := exp - ((exp%3 + 3) % 3)
+	if eng < siPrefixExp[0] || eng > siPrefixExp[len(siPrefixExp)-1] {
+		return fmtE(dst, neg, d, prec+exp-eng, 'e')
+	}
+
+	dst, eng = appendEngineering(dst, neg, d, prec)
+	return append(dst, siPrefix[(eng-siPrefixExp[0])/3]...)
+}
+
 // %f: -ddddddd.ddddd
 func fmtF(dst []byte, neg bool, d decimalSlice, prec int) []byte {
 	// sign
@@ -583,3 +824,445 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// A NumberFormat describes how FormatFloatLocale, AppendFloatLocale, and
+// AppendIntLocale should render the digits produced by the usual ftoa/itoa
+// pipeline: which glyphs to use for the decimal point, the digit-group
+// separator, and the minus sign, and how to size the digit groups.
+//
+// Grouping is applied to the integer part of 'f' and 'g'/'G' output but not
+// to the 'e', 'E', 'b', 'x', or 'X' formats, whose digit streams are not
+// meant to be grouped.
+type NumberFormat struct {
+	// Decimal is the decimal separator, such as "." or ",". An empty
+	// Decimal leaves the default "." in place.
+	Decimal string
+
+	// Grouping is the digit-group separator, such as "," or ".", or " ".
+	// An empty Grouping disables digit grouping entirely.
+	Grouping string
+
+	// PrimaryGroupSize is the size of the rightmost (ones) digit group,
+	// usually 3. A value <= 0 disables digit grouping.
+	PrimaryGroupSize int
+
+	// SecondaryGroupSize is the size of the digit groups to the left of
+	// the primary group. Western grouping repeats the primary size
+	// (1,234,567); Indian-style grouping uses 2 (12,34,567). A value <= 0
+	// reuses PrimaryGroupSize.
+	SecondaryGroupSize int
+
+	// Minus is the glyph used in place of '-' for negative values. An
+	// empty Minus leaves the default "-" in place.
+	Minus string
+
+	// Inf and NaN, if non-empty, replace the "+Inf"/"-Inf" and "NaN"
+	// strings that genericFtoa produces for non-finite values. The sign
+	// of Inf is still taken from Minus.
+	Inf, NaN string
+}
+
+// FormatFloatLocale is like FormatFloat but renders the digit stream using
+// the decimal separator, digit grouping, and minus sign described by nf.
+func FormatFloatLocale(f float64, fmt byte, prec, bitSize int, nf NumberFormat) string {
+	return string(AppendFloatLocale(make([]byte, 0, max(prec+4, 24)), f, fmt, prec, bitSize, nf))
+}
+
+// AppendFloatLocale is like AppendFloat but renders the digit stream using
+// the decimal separator, digit grouping, and minus sign described by nf.
+func AppendFloatLocale(dst []byte, f float64, fmt byte, prec, bitSize int, nf NumberFormat) []byte {
+	raw := genericFtoa(make([]byte, 0, max(prec+4, 24)), f, fmt, prec, bitSize)
+	return appendLocale(dst, raw, fmt, nf)
+}
+
+// FormatIntLocale is like FormatInt(i, 10) but renders the digit stream
+// using the digit grouping and minus sign described by nf.
+func FormatIntLocale(i int64, nf NumberFormat) string {
+	return string(AppendIntLocale(nil, i, nf))
+}
+
+// AppendIntLocale is like AppendInt(dst, i, 10) but renders the digit
+// stream using the digit grouping and minus sign described by nf.
+func AppendIntLocale(dst []byte, i int64, nf NumberFormat) []byte {
+	raw := AppendInt(nil, i, 10)
+	neg := len(raw) > 0 && raw[0] == '-'
+	if neg {
+		raw = raw[1:]
+	}
+	if neg {
+		dst = appendMinus(dst, nf)
+	}
+	return appendGrouped(dst, raw, nf)
+}
+
+// appendLocale rewrites raw, the plain-ASCII output of genericFtoa, onto
+// dst according to nf. raw is never aliased into dst's backing array by
+// the caller, so it is safe to read from while appending to dst.
+func appendLocale(dst, raw []byte, fmt byte, nf NumberFormat) []byte {
+	switch string(raw) {
+	case "NaN":
+		if nf.NaN != "" {
+			return append(dst, nf.NaN...)
+		}
+		return append(dst, raw...)
+	case "+Inf", "-Inf":
+		if raw[0] == '-' {
+			dst = appendMinus(dst, nf)
+		}
+		if nf.Inf != "" {
+			return append(dst, nf.Inf...)
+		}
+		return append(dst, raw[1:]...)
+	}
+
+	neg := len(raw) > 0 && raw[0] == '-'
+	if neg {
+		raw = raw[1:]
+	}
+
+	// 'b', 'x', and 'X' are not decimal representations; leave their
+	// digit streams untouched aside from the sign glyph.
+	if fmt == 'b' || fmt == 'x' || fmt == 'X' {
+		if neg {
+			dst = appendMinus(dst, nf)
+		}
+		return append(dst, raw...)
+	}
+
+	mant, exp := raw, []byte(nil)
+	if i := byteIndex(raw, 'e'); i >= 0 {
+		mant, exp = raw[:i], raw[i:]
+	} else if i := byteIndex(raw, 'E'); i >= 0 {
+		mant, exp = raw[:i], raw[i:]
+	}
+
+	intPart, fracPart := mant, []byte(nil)
+	if i := byteIndex(mant, '.'); i >= 0 {
+		intPart, fracPart = mant[:i], mant[i+1:]
+	}
+
+	if neg {
+		dst = appendMinus(dst, nf)
+	}
+	dst = appendGrouped(dst, intPart, nf)
+	if fracPart != nil {
+		if nf.Decimal != "" {
+			dst = append(dst, nf.Decimal...)
+		} else {
+			dst = append(dst, '.')
+		}
+		dst = append(dst, fracPart...)
+	}
+	return append(dst, exp...)
+}
+
+// appendGrouped appends digits to dst, inserting nf.Grouping every
+// PrimaryGroupSize digits from the right and every SecondaryGroupSize
+// digits beyond that (CLDR-style grouping, e.g. Indian 3;2 grouping).
+func appendGrouped(dst, digits []byte, nf NumberFormat) []byte {
+	primary := nf.PrimaryGroupSize
+	if primary <= 0 || nf.Grouping == "" || len(digits) <= primary {
+		return append(dst, digits...)
+	}
+	secondary := nf.SecondaryGroupSize
+	if secondary <= 0 {
+		secondary = primary
+	}
+
+	// Peel groups off the right end of digits; the last one appended to
+	// groups is the leftmost (and possibly short) group.
+	var groups [][]byte
+	rest := digits
+	size := primary
+	for len(rest) > size {
+		split := len(rest) - size
+		groups = append(groups, rest[split:])
+		rest = rest[:split]
+		size = secondary
+	}
+	groups = append(groups, rest)
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		if i != len(groups)-1 {
+			dst = append(dst, nf.Grouping...)
+		}
+		dst = append(dst, groups[i]...)
+	}
+	return dst
+}
+
+func appendMinus(dst []byte, nf NumberFormat) []byte {
+	if nf.Minus != "" {
+		return append(dst, nf.Minus...)
+	}
+	return append(dst, '-')
+}
+
+func byteIndex(s []byte, c byte) int {
+	for i, b := range s {
+		if b == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// Uint128 carries the raw 128-bit pattern of an IEEE 754-2008 decimal128
+// value (or, with Hi left zero, a decimal64 value) into FormatDecimal.
+// Hi holds the most significant 64 bits, Lo the least significant 64.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+// A DecimalEncoding selects how an IEEE 754-2008 decimal float packs its
+// coefficient digits into the trailing significand field.
+type DecimalEncoding int
+
+const (
+	// BID packs the coefficient as a single plain binary integer.
+	BID DecimalEncoding = iota
+	// DPD packs the coefficient as a sequence of 10-bit declets, each
+	// holding 3 decimal digits. Not yet implemented by AppendDecimal.
+	DPD
+)
+
+const (
+	decSpecialNone = iota
+	decSpecialInf
+	decSpecialNaN
+)
+
+// decimalInfo describes the BID bit layout: a 1-bit sign followed by
+// either [exponent][coefficient] directly, or, when the coefficient would
+// not otherwise fit, a 2-bit '11' marker, [exponent], and a coefficient
+// with an implicit leading 0b100 — see decodeBID.
+type decimalInfo struct {
+	bitSize int
+	expBits uint // full exponent width (not just a continuation field)
+	bias    int
+}
+
+var decimal64info = decimalInfo{bitSize: 64, expBits: 10, bias: 398}
+var decimal128info = decimalInfo{bitSize: 128, expBits: 14, bias: 6176}
+
+// FormatDecimal converts the IEEE 754-2008 decimal floating-point value
+// held in bits (decimal64 if bitSize is 64, decimal128 if bitSize is 128)
+// to a string, the way FormatFloat does for a binary float32/float64.
+// enc selects how bits packs its coefficient; only BID is implemented.
+//
+// fmt and prec behave as in FormatFloat, except that prec == -1 returns
+// the coefficient's exact digits rather than a shortest round-tripping
+// representation, since a decimal float carries no binary rounding to
+// reconstruct. Only the decimal formats ('e', 'E', 'f', 'g', 'G', 'n',
+// and 's') are supported; 'b', 'x', and 'X' produce '%' followed by the
+// format byte, as an unknown format does.
+func FormatDecimal(bits Uint128, bitSize int, enc DecimalEncoding, fmt byte, prec int) string {
+	return string(AppendDecimal(make([]byte, 0, max(prec+4, 24)), bits, bitSize, enc, fmt, prec))
+}
+
+// AppendDecimal is like FormatDecimal but appends to and returns dst.
+//
+// It decodes the sign, exponent, and coefficient fields (see decodeBID),
+// and then feeds the coefficient into the same decimal.Assign + fmtE/fmtF/fmtG
+// machinery genericFtoa uses for binary floats, via decimal's ShiftDecimal
+// method (Shift's base-10 sibling, used here instead of Shift because a
+// decimal exponent needs no binary-to-decimal conversion). The coefficient
+// itself is assigned through assignUint128, decimal.Assign's two-word
+// counterpart, since a decimal128 coefficient can carry up to 34 digits
+// and does not fit a uint64.
+//
+// AppendDecimal panics if enc is DPD (not yet implemented).
+func AppendDecimal(dst []byte, bits Uint128, bitSize int, enc DecimalEncoding, fmt byte, prec int) []byte {
+	var info *decimalInfo
+	switch bitSize {
+	case 64:
+		info = &decimal64info
+	case 128:
+		info = &decimal128info
+	default:
+		panic("strconv: illegal AppendDecimal/FormatDecimal bitSize")
+	}
+	if enc != BID {
+		panic("strconv: AppendDecimal/FormatDecimal: DPD encoding is not yet implemented")
+	}
+
+	neg, special, exp, coeff := decodeBID(bits, info)
+	switch special {
+	case decSpecialNaN:
+		return append(dst, "NaN"...)
+	case decSpecialInf:
+		if neg {
+			return append(dst, "-Inf"...)
+		}
+		return append(dst, "+Inf"...)
+	}
+
+	d := new(decimal)
+	assignUint128(d, coeff)
+	d.ShiftDecimal(exp)
+	digs := decimalSlice{d: d.d[:], nd: d.nd, dp: d.dp}
+
+	shortest := prec < 0
+	if shortest {
+		switch fmt {
+		case 'e', 'E':
+			prec = max(digs.nd-1, 0)
+		case 'n', 's':
+			prec = max(digs.nd-engineeringLead(digs.dp, digs.nd), 0)
+		case 'f':
+			prec = max(digs.nd-digs.dp, 0)
+		case 'g', 'G':
+			prec = digs.nd
+		}
+	} else {
+		switch fmt {
+		case 'e', 'E':
+			d.Round(prec + 1)
+		case 'n', 's':
+			d.Round(engineeringLead(d.dp, d.nd) + prec)
+		case 'f':
+			d.Round(d.dp + prec)
+		case 'g', 'G':
+			if prec == 0 {
+				prec = 1
+			}
+			d.Round(prec)
+		}
+		digs = decimalSlice{d: d.d[:], nd: d.nd, dp: d.dp}
+	}
+	return formatDigits(dst, shortest, neg, digs, prec, fmt)
+}
+
+// decodeBID splits a BID-encoded decimal64/decimal128 bit pattern into a
+// sign, a special-value marker, a base-10 exponent, and a coefficient.
+// The coefficient is returned as a Uint128: a decimal128 coefficient can
+// carry up to 34 decimal digits, i.e. up to 113 bits, so it does not fit
+// in a uint64 the way a decimal64 coefficient always does.
+//
+// Layout, confirmed against the canonical BID64 encoding of 1
+// (0x31C0000000000001 decodes to sign 0, a contiguous 10-bit exponent
+// field of 398 i.e. unbiased 0, and a contiguous 53-bit coefficient of
+// 1): the first two bits after the sign select the form.
+//
+//   - If they are not both 1: the exponent is the info.expBits bits
+//     immediately after the sign, and the coefficient is every bit after
+//     that, taken as a plain unsigned binary integer.
+//   - If they are both 1 (and the field isn't the all-ones Inf/NaN
+//     pattern): those two bits are a marker, consumed but otherwise
+//     unused; the exponent is the next info.expBits bits, and the
+//     coefficient is an implicit leading 0b100 followed by every
+//     remaining bit. This trades two bits of coefficient range for the
+//     marker so the rare large coefficients that need it still fit.
+func decodeBID(bits Uint128, info *decimalInfo) (neg bool, special int, exp int, coeff Uint128) {
+	v := bits
+	if info.bitSize == 64 {
+		// Left-align the 64 significant bits at the top of v so the
+		// bit-index helpers below don't need to special-case bitSize.
+		v = Uint128{Hi: bits.Lo, Lo: 0}
+	}
+
+	neg = bitAt(v, 0) != 0
+	g0, g1, g2, g3, g4 := bitAt(v, 1), bitAt(v, 2), bitAt(v, 3), bitAt(v, 4), bitAt(v, 5)
+
+	if g0 == 1 && g1 == 1 && g2 == 1 && g3 == 1 {
+		if g4 == 1 {
+			special = decSpecialNaN
+		} else {
+			special = decSpecialInf
+		}
+		return neg, special, 0, Uint128{}
+	}
+
+	expStart := uint(1)
+	var prefix uint64
+	if g0 == 1 && g1 == 1 {
+		expStart = 3
+		prefix = 0b100
+	}
+
+	expField := fieldU64(v, expStart, info.expBits)
+	exp = int(expField) - info.bias
+
+	coeffStart := expStart + info.expBits
+	coeffWidth := uint(info.bitSize) - coeffStart
+	trail := fieldU128(v, coeffStart, coeffWidth)
+	coeff = or128(trail, shl128(Uint128{Lo: prefix}, coeffWidth))
+	return neg, decSpecialNone, exp, coeff
+}
+
+// shl128 returns v<<n, for n in [0, 128).
+func shl128(v Uint128, n uint) Uint128 {
+	switch {
+	case n == 0:
+		return v
+	case n < 64:
+		return Uint128{Hi: v.Hi<<n | v.Lo>>(64-n), Lo: v.Lo << n}
+	default:
+		return Uint128{Hi: v.Lo << (n - 64), Lo: 0}
+	}
+}
+
+// or128 returns the bitwise OR of a and b.
+func or128(a, b Uint128) Uint128 {
+	return Uint128{Hi: a.Hi | b.Hi, Lo: a.Lo | b.Lo}
+}
+
+// assignUint128 sets d's digits to the decimal expansion of v, the
+// two-word counterpart to decimal.Assign(uint64) that a decimal128
+// coefficient needs. It splits v into a high quotient and a 19-digit low
+// remainder — 10^19 still fits in a uint64, and so does the quotient,
+// since even decimal128's widest (34-digit) coefficient leaves at most
+// 15-16 digits above the low 19 — seeds the high-order digits with the
+// ordinary Assign, then writes the zero-padded remainder digits directly
+// after them.
+func assignUint128(d *decimal, v Uint128) {
+	const chunkDigits = 19
+	const chunkMod uint64 = 10000000000000000000 // 10^19
+
+	quo, rem := bits.Div64(v.Hi, v.Lo, chunkMod)
+	if quo == 0 {
+		d.Assign(rem)
+		return
+	}
+	d.Assign(quo)
+	// d.Assign trims trailing zeros from d.nd but leaves d.dp at the
+	// untrimmed digit count (e.g. quotient 10 has nd=1, dp=2), so the low
+	// chunk's digits must be placed starting at dp, not nd, or they'd
+	// overwrite the trimmed zero instead of following it.
+	hiDigits := d.dp
+	for i := 0; i < chunkDigits; i++ {
+		d.d[hiDigits+chunkDigits-1-i] = byte(rem%10) + '0'
+		rem /= 10
+	}
+	d.nd = hiDigits + chunkDigits
+	d.dp = d.nd
+}
+
+// bitAt returns the bit at position idx (0 = most significant) of the
+// 128-bit value v.
+func bitAt(v Uint128, idx uint) uint64 {
+	if idx < 64 {
+		return (v.Hi >> (63 - idx)) & 1
+	}
+	return (v.Lo >> (127 - idx)) & 1
+}
+
+// fieldU64 extracts width (<= 64) bits of v starting at bit index start
+// (0 = most significant bit of v) and returns them right-aligned.
+func fieldU64(v Uint128, start, width uint) uint64 {
+	var r uint64
+	for i := uint(0); i < width; i++ {
+		r = r<<1 | bitAt(v, start+i)
+	}
+	return r
+}
+
+// fieldU128 is fieldU64 for fields that may be wider than 64 bits.
+func fieldU128(v Uint128, start, width uint) Uint128 {
+	var r Uint128
+	for i := uint(0); i < width; i++ {
+		r.Hi = r.Hi<<1 | r.Lo>>63
+		r.Lo = r.Lo<<1 | bitAt(v, start+i)
+	}
+	return r
+}
@@ -0,0 +1,388 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package strconv_test
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var western = strconv.NumberFormat{Decimal: ".", Grouping: ",", PrimaryGroupSize: 3, SecondaryGroupSize: 3}
+var european = strconv.NumberFormat{Decimal: ",", Grouping: ".", PrimaryGroupSize: 3, SecondaryGroupSize: 3}
+var indian = strconv.NumberFormat{Decimal: ".", Grouping: ",", PrimaryGroupSize: 3, SecondaryGroupSize: 2}
+
+var formatFloatLocaleTests = []struct {
+	f    float64
+	fmt  byte
+	prec int
+	nf   strconv.NumberFormat
+	want string
+}{
+	{1234567.89, 'f', 2, western, "1,234,567.89"},
+	{1234567.89, 'f', 2, european, "1.234.567,89"},
+	{1234567.89, 'f', 2, indian, "12,34,567.89"},
+	{-1234567.89, 'f', 2, western, "-1,234,567.89"},
+	{-1234567.89, 'f', 2, strconv.NumberFormat{Minus: "−", Grouping: ",", PrimaryGroupSize: 3}, "−1,234,567.89"},
+	{math.Copysign(0, -1), 'f', 1, western, "-0.0"},
+	{math.NaN(), 'f', 2, strconv.NumberFormat{NaN: "n/a"}, "n/a"},
+	{math.Inf(1), 'f', 2, strconv.NumberFormat{Inf: "infinity"}, "infinity"},
+	{math.Inf(-1), 'f', 2, strconv.NumberFormat{Inf: "infinity", Minus: "−"}, "−infinity"},
+	{1234567.89, 'e', 2, western, "1.23e+06"},
+}
+
+func TestFormatFloatLocale(t *testing.T) {
+	for _, tt := range formatFloatLocaleTests {
+		got := strconv.FormatFloatLocale(tt.f, tt.fmt, tt.prec, 64, tt.nf)
+		if got != tt.want {
+			t.Errorf("FormatFloatLocale(%v, %q, %d, 64, %+v) = %q, want %q",
+				tt.f, tt.fmt, tt.prec, tt.nf, got, tt.want)
+		}
+	}
+}
+
+// TestFormatFloatLocaleNoGrouping checks the 'e' and 'x' formats are never
+// grouped, even though their digit streams can be longer than a group size.
+func TestFormatFloatLocaleNoGrouping(t *testing.T) {
+	nf := strconv.NumberFormat{Grouping: ",", PrimaryGroupSize: 3}
+	for _, fmtByte := range []byte{'e', 'x'} {
+		got := strconv.FormatFloatLocale(123456789.125, fmtByte, -1, 64, nf)
+		if strings.Contains(got, ",") {
+			t.Errorf("FormatFloatLocale(..., %q, ...) = %q, contains a grouping separator", fmtByte, got)
+		}
+		want := string(strconv.AppendFloat(nil, 123456789.125, fmtByte, -1, 64))
+		if got != want {
+			t.Errorf("FormatFloatLocale(..., %q, ...) = %q, want ungrouped %q", fmtByte, got, want)
+		}
+	}
+}
+
+// TestAppendGrouped exercises the grouping logic directly against literal
+// digit strings, independent of how the digits were produced.
+func TestAppendGrouped(t *testing.T) {
+	tests := []struct {
+		digits string
+		nf     strconv.NumberFormat
+		want   string
+	}{
+		{"1234567", western, "1,234,567"},
+		{"1234567", indian, "12,34,567"},
+		{"1234567", european, "1.234.567"},
+		{"12", western, "12"},   // shorter than one group: untouched
+		{"123", western, "123"}, // exactly one group: untouched
+		{"1234", western, "1,234"},
+		{"1234567", strconv.NumberFormat{}, "1234567"}, // no Grouping set: disabled
+		{"1234567", strconv.NumberFormat{Grouping: ",", PrimaryGroupSize: 0}, "1234567"},
+	}
+	for _, tt := range tests {
+		got := string(strconv.AppendGrouped(nil, []byte(tt.digits), tt.nf))
+		if got != tt.want {
+			t.Errorf("appendGrouped(%q, %+v) = %q, want %q", tt.digits, tt.nf, got, tt.want)
+		}
+	}
+}
+
+// TestAppendLocale exercises appendLocale directly against literal raw
+// genericFtoa-shaped output, independent of how the digits were produced.
+func TestAppendLocale(t *testing.T) {
+	tests := []struct {
+		raw  string
+		fmt  byte
+		nf   strconv.NumberFormat
+		want string
+	}{
+		{"1234567.89", 'f', western, "1,234,567.89"},
+		{"1234567.89", 'f', european, "1.234.567,89"},
+		{"-1234567.89", 'f', western, "-1,234,567.89"},
+		{"-1234567.89", 'f', strconv.NumberFormat{Minus: "−", Grouping: ",", PrimaryGroupSize: 3}, "−1,234,567.89"},
+		{"-0", 'f', western, "-0"},
+		{"NaN", 'f', strconv.NumberFormat{NaN: "n/a"}, "n/a"},
+		{"+Inf", 'f', strconv.NumberFormat{Inf: "infinity"}, "infinity"},
+		{"-Inf", 'f', strconv.NumberFormat{Inf: "infinity", Minus: "−"}, "−infinity"},
+		{"1.23456e+06", 'e', western, "1.23456e+06"},
+		{"1.fe3ffffffffffp+07", 'x', western, "1.fe3ffffffffffp+07"},
+	}
+	for _, tt := range tests {
+		got := string(strconv.AppendLocale(nil, []byte(tt.raw), tt.fmt, tt.nf))
+		if got != tt.want {
+			t.Errorf("appendLocale(%q, %q, %+v) = %q, want %q", tt.raw, tt.fmt, tt.nf, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIntLocale(t *testing.T) {
+	tests := []struct {
+		i    int64
+		nf   strconv.NumberFormat
+		want string
+	}{
+		{1234567, western, "1,234,567"},
+		{1234567, indian, "12,34,567"},
+		{-1234567, strconv.NumberFormat{Grouping: ",", PrimaryGroupSize: 3, Minus: "−"}, "−1,234,567"},
+		{42, strconv.NumberFormat{}, "42"},
+	}
+	for _, tt := range tests {
+		got := strconv.FormatIntLocale(tt.i, tt.nf)
+		if got != tt.want {
+			t.Errorf("FormatIntLocale(%d, %+v) = %q, want %q", tt.i, tt.nf, got, tt.want)
+		}
+	}
+}
+
+// floatFormatterBenchVals are shortest-mode inputs exercised by both
+// BenchmarkAppendFloat and BenchmarkFloatFormatter, so the two report
+// comparable numbers.
+var floatFormatterBenchVals = []float64{
+	1,
+	3.14159265358979,
+	1e100,
+	1e-100,
+	123456789.123456789,
+}
+
+func BenchmarkAppendFloat(b *testing.B) {
+	dst := make([]byte, 0, 32)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = strconv.AppendFloat(dst[:0], floatFormatterBenchVals[i%len(floatFormatterBenchVals)], 'g', -1, 64)
+	}
+}
+
+func BenchmarkFloatFormatter(b *testing.B) {
+	var fo strconv.FloatFormatter
+	dst := make([]byte, 0, 32)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = fo.Format(dst[:0], floatFormatterBenchVals[i%len(floatFormatterBenchVals)], 'g', -1, 64)
+	}
+}
+
+// TestFloatFormatterAllocs asserts the claim BenchmarkFloatFormatter exists
+// to measure: repeated shortest-mode Format calls on a reused FloatFormatter
+// do not allocate, since they stay on the Ryu path and never touch bigFtoa's
+// new(decimal).
+func TestFloatFormatterAllocs(t *testing.T) {
+	var fo strconv.FloatFormatter
+	dst := make([]byte, 0, 32)
+	n := testing.AllocsPerRun(100, func() {
+		dst = fo.Format(dst[:0], 3.14159265358979, 'g', -1, 64)
+	})
+	if n != 0 {
+		t.Errorf("FloatFormatter.Format allocated %v times per call, want 0", n)
+	}
+}
+
+// fmtNTests cover appendEngineering's exponent rounding and the resulting
+// 1/2/3-leading-digit redistribution: the same digit stream lands with a
+// different number of digits before the decimal point depending on dp.
+var fmtNTests = []struct {
+	digits string
+	dp     int
+	prec   int
+	want   string
+}{
+	{"12345", 1, 3, "1.234e+00"},    // exp 0, eng 0: 1 leading digit
+	{"12345", 2, 3, "12.345e+00"},   // exp 1, eng 0: 2 leading digits
+	{"12345", 3, 3, "123.450e+00"},  // exp 2, eng 0: 3 leading digits
+	{"12345", 8, 4, "12.3450e+06"},  // exp 7 rounds down to eng 6
+	{"12345", -2, 4, "1.2345e-03"},  // exp -3, eng -3: already a multiple of 3
+	{"12345", -1, 4, "12.3450e-03"}, // exp -2 rounds down to eng -3
+}
+
+func TestFmtN(t *testing.T) {
+	for _, tt := range fmtNTests {
+		d := strconv.NewDecimalSlice([]byte(tt.digits), len(tt.digits), tt.dp)
+		got := string(strconv.FmtN(nil, false, d, tt.prec))
+		if got != tt.want {
+			t.Errorf("fmtN(digits=%q, dp=%d, prec=%d) = %q, want %q", tt.digits, tt.dp, tt.prec, got, tt.want)
+		}
+	}
+}
+
+func TestFmtS(t *testing.T) {
+	tests := []struct {
+		digits string
+		dp     int
+		prec   int
+		want   string
+	}{
+		{"12345", -5, 4, "1.2345µ"}, // eng -6: the µ (multi-byte) prefix
+		{"12345", 1, 3, "1.234"},    // eng 0: empty prefix
+		{"12345", 4, 3, "1.234k"},   // eng 3: k prefix
+	}
+	for _, tt := range tests {
+		d := strconv.NewDecimalSlice([]byte(tt.digits), len(tt.digits), tt.dp)
+		got := string(strconv.FmtS(nil, false, d, tt.prec))
+		if got != tt.want {
+			t.Errorf("fmtS(digits=%q, dp=%d, prec=%d) = %q, want %q", tt.digits, tt.dp, tt.prec, got, tt.want)
+		}
+	}
+
+	// Outside the y..Y prefix range (eng > 24 or eng < -24), fmtS falls
+	// back to plain 'e' notation instead of an SI prefix.
+	outOfRange := strconv.NewDecimalSlice([]byte("1"), 1, 31)
+	got := string(strconv.FmtS(nil, false, outOfRange, 0))
+	want := string(strconv.FmtE(nil, false, outOfRange, 0, 'e'))
+	if got != want {
+		t.Errorf("fmtS out of SI range = %q, want fmtE fallback %q", got, want)
+	}
+}
+
+// formatFloatEngineeringTests exercise 'n'/'s' through FormatFloat end to
+// end, across exponent phases that land on each of the 1/2/3 leading-digit
+// cases (including one that carries into a new leading digit on rounding),
+// so a fixed-digit-count regression in appendFtoa's Ryu request or
+// bigFtoa's rounding shows up here even though TestFmtN/TestFmtS build
+// their decimalSlice by hand, bypassing both, and can't catch it.
+var formatFloatEngineeringTests = []struct {
+	f    float64
+	fmt  byte
+	prec int
+	want string
+}{
+	{12345.678, 'n', 3, "12.346e+03"},     // lead 2
+	{999.5, 'n', 2, "999.50e+00"},         // lead 3
+	{7, 'n', 0, "7e+00"},                  // lead 1, prec 0
+	{0.000123456, 'n', 4, "123.4560e-06"}, // lead 3, negative eng
+	{999999, 'n', 2, "1.00e+06"},          // lead 1 after a rounding carry
+	{12345.678, 's', 3, "12.346k"},        // lead 2, SI prefix
+	{0.000123456, 's', 4, "123.4560µ"},    // lead 3, SI prefix
+}
+
+func TestFormatFloatEngineering(t *testing.T) {
+	for _, tt := range formatFloatEngineeringTests {
+		got := strconv.FormatFloat(tt.f, tt.fmt, tt.prec, 64)
+		if got != tt.want {
+			t.Errorf("FormatFloat(%v, %q, %d, 64) = %q, want %q", tt.f, tt.fmt, tt.prec, got, tt.want)
+		}
+	}
+}
+
+// decimalBIDTests are round-trip vectors for decodeBID, cross-checked
+// against the canonical BID64 bit patterns (sign | 10-bit exponent |
+// 53-bit coefficient, biased by 398).
+var decimalBIDTests = []struct {
+	hex       uint64
+	neg       bool
+	special   int
+	exp       int
+	coeff     uint64
+	formatted string // FormatDecimal(..., 'g', -1)
+}{
+	{0x31C0000000000001, false, strconv.DecSpecialNone, 0, 1, "1"},
+	{0x31C0000000000002, false, strconv.DecSpecialNone, 0, 2, "2"},
+	{0xB1C0000000000001, true, strconv.DecSpecialNone, 0, 1, "-1"},
+	{0x31C0000000000064, false, strconv.DecSpecialNone, 0, 100, "100"},
+	// exponent field biased 400 (unbiased 2), coeff 1 -> 1 * 10^2
+	{0x3200000000000001, false, strconv.DecSpecialNone, 2, 1, "100"},
+}
+
+func TestDecodeBID(t *testing.T) {
+	for _, tt := range decimalBIDTests {
+		neg, special, exp, coeff := strconv.DecodeBID(strconv.Uint128{Lo: tt.hex}, &strconv.Decimal64Info)
+		if neg != tt.neg || special != tt.special || exp != tt.exp || coeff.Hi != 0 || coeff.Lo != tt.coeff {
+			t.Errorf("decodeBID(%#x) = %v, %v, %v, %v; want %v, %v, %v, %v",
+				tt.hex, neg, special, exp, coeff, tt.neg, tt.special, tt.exp, tt.coeff)
+		}
+	}
+}
+
+func TestFormatDecimalBID(t *testing.T) {
+	for _, tt := range decimalBIDTests {
+		got := strconv.FormatDecimal(strconv.Uint128{Lo: tt.hex}, 64, strconv.BID, 'g', -1)
+		if got != tt.formatted {
+			t.Errorf("FormatDecimal(%#x, 64, BID, 'g', -1) = %q, want %q", tt.hex, got, tt.formatted)
+		}
+	}
+}
+
+// decimalBID128Tests exercise the decimal128 layout, where the coefficient
+// is up to 113 bits and no longer fits in a uint64 the way decimal64's
+// always does (the bug this set of tests was added to catch: decodeBID
+// used to report every decimal128 coefficient as "doesn't fit").
+var decimalBID128Tests = []struct {
+	hi, lo    uint64
+	neg       bool
+	exp       int
+	coeffHi   uint64
+	coeffLo   uint64
+	formatted string // FormatDecimal(..., 'f', -1)
+}{
+	// sign 0, exponent field 6176 (unbiased 0), coefficient 1.
+	{0x3040000000000000, 0x1, false, 0, 0, 1, "1"},
+	// same exponent, coefficient 123.
+	{0x3040000000000000, 0x7b, false, 0, 0, 123, "123"},
+	// sign 1, coefficient 5.
+	{0xB040000000000000, 0x5, true, 0, 0, 5, "-5"},
+	// coefficient 10^20, which does not fit in 64 bits.
+	{0x3040000000000005, 0x6BC75E2D63100000, false, 0, 0x5, 0x6BC75E2D63100000, "100000000000000000000"},
+	// the widest decimal128 coefficient, 10^34-1 (34 nines).
+	{0x3041ED09BEAD87C0, 0x378D8E63FFFFFFFF, false, 0, 0x1ED09BEAD87C0, 0x378D8E63FFFFFFFF, "9999999999999999999999999999999999"},
+}
+
+func TestDecodeBID128(t *testing.T) {
+	for _, tt := range decimalBID128Tests {
+		neg, special, exp, coeff := strconv.DecodeBID(strconv.Uint128{Hi: tt.hi, Lo: tt.lo}, &strconv.Decimal128Info)
+		if neg != tt.neg || special != strconv.DecSpecialNone || exp != tt.exp || coeff.Hi != tt.coeffHi || coeff.Lo != tt.coeffLo {
+			t.Errorf("decodeBID(%#x_%016x) = neg %v special %v exp %v coeff %#x_%016x; want neg %v exp %v coeff %#x_%016x",
+				tt.hi, tt.lo, neg, special, exp, coeff.Hi, coeff.Lo, tt.neg, tt.exp, tt.coeffHi, tt.coeffLo)
+		}
+	}
+}
+
+func TestFormatDecimalBID128(t *testing.T) {
+	for _, tt := range decimalBID128Tests {
+		// 'f' rather than 'g': 'g' falls back to scientific notation once
+		// the exponent reaches 6 in shortest mode, which would obscure
+		// whether the full coefficient actually made it through.
+		got := strconv.FormatDecimal(strconv.Uint128{Hi: tt.hi, Lo: tt.lo}, 128, strconv.BID, 'f', -1)
+		if got != tt.formatted {
+			t.Errorf("FormatDecimal(%#x_%016x, 128, BID, 'f', -1) = %q, want %q", tt.hi, tt.lo, got, tt.formatted)
+		}
+	}
+}
+
+// ftoaFFixedTests cover the 'f' fast path's handling of sdigs.dp+prec < 1,
+// where the rounding position sits at or above the most significant digit
+// and the result can round up into a new leading digit.
+var ftoaFFixedTests = []struct {
+	f    float64
+	prec int
+	want string
+}{
+	{0.09, 1, "0.1"},
+	{0.05, 1, "0.1"},
+	{0.04, 1, "0.0"},
+	{0.009, 2, "0.01"},
+	{-0.09, 1, "-0.1"},
+}
+
+func TestFtoaFFixedRounding(t *testing.T) {
+	for _, tt := range ftoaFFixedTests {
+		got := strconv.FormatFloat(tt.f, 'f', tt.prec, 64)
+		if got != tt.want {
+			t.Errorf("FormatFloat(%v, 'f', %d, 64) = %q, want %q", tt.f, tt.prec, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDecimalInfNaN(t *testing.T) {
+	// Combination field 11110 selects Inf, 11111 selects NaN (top 5 bits
+	// after the sign), independent of the remaining bits.
+	const infBits = uint64(0b0_11110) << 58
+	const nanBits = uint64(0b0_11111) << 58
+
+	if got := strconv.FormatDecimal(strconv.Uint128{Lo: infBits}, 64, strconv.BID, 'g', -1); got != "+Inf" {
+		t.Errorf("FormatDecimal(Inf) = %q, want %q", got, "+Inf")
+	}
+	neg := infBits | 1<<63
+	if got := strconv.FormatDecimal(strconv.Uint128{Lo: neg}, 64, strconv.BID, 'g', -1); got != "-Inf" {
+		t.Errorf("FormatDecimal(-Inf) = %q, want %q", got, "-Inf")
+	}
+	if got := strconv.FormatDecimal(strconv.Uint128{Lo: nanBits}, 64, strconv.BID, 'g', -1); got != "NaN" {
+		t.Errorf("FormatDecimal(NaN) = %q, want %q", got, "NaN")
+	}
+}